@@ -0,0 +1,83 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package views
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/terraform/internal/configs/configload"
+	"github.com/hashicorp/terraform/internal/moduletest"
+	"github.com/hashicorp/terraform/internal/tfdiags"
+)
+
+// artifactConstructors maps each format name accepted by a repeatable
+// "-report=<format>:<path>" flag to the constructor that builds the
+// corresponding Artifact. Adding a new report format means adding one
+// entry here.
+var artifactConstructors = map[string]func(filename string, configLoader *configload.Loader, meta JUnitXMLReportMeta) Artifact{
+	"junit": func(filename string, configLoader *configload.Loader, meta JUnitXMLReportMeta) Artifact {
+		return NewJUnitXMLFile(filename, configLoader, meta)
+	},
+	"tap": func(filename string, configLoader *configload.Loader, meta JUnitXMLReportMeta) Artifact {
+		return NewTAPFile(filename, configLoader)
+	},
+	"json": func(filename string, configLoader *configload.Loader, meta JUnitXMLReportMeta) Artifact {
+		return NewJSONReportFile(filename, configLoader)
+	},
+	"github-annotations": func(filename string, configLoader *configload.Loader, meta JUnitXMLReportMeta) Artifact {
+		return NewGitHubAnnotationsFile(filename, configLoader)
+	},
+}
+
+// ParseReportFlag splits one occurrence of a repeatable
+// "-report=<format>:<path>" flag into its format and path, validating
+// that the format is one NewArtifact can build. This lets
+// "terraform test -report=junit:out.xml -report=json:out.json -report=tap:out.tap"
+// request any combination of formats in a single run.
+func ParseReportFlag(raw string) (format, path string, err error) {
+	format, path, ok := strings.Cut(raw, ":")
+	if !ok || format == "" || path == "" {
+		return "", "", fmt.Errorf("invalid -report value %q: expected <format>:<path>", raw)
+	}
+	if _, ok := artifactConstructors[format]; !ok {
+		return "", "", fmt.Errorf("unrecognized report format %q", format)
+	}
+	return format, path, nil
+}
+
+// NewArtifact builds the Artifact registered for format, writing to
+// filename. Callers should validate format with ParseReportFlag first;
+// NewArtifact panics on an unrecognized format, since at that point it
+// indicates a bug in the caller rather than bad user input.
+func NewArtifact(format, filename string, configLoader *configload.Loader, meta JUnitXMLReportMeta) Artifact {
+	ctor, ok := artifactConstructors[format]
+	if !ok {
+		panic(fmt.Sprintf("unrecognized report format %q", format))
+	}
+	return ctor(filename, configLoader, meta)
+}
+
+// SaveReports is the single entry point a "terraform test" command's flag
+// handling needs to call once it's collected every "-report=<format>:<path>"
+// occurrence: it parses each raw flag value, builds the Artifact it names,
+// and saves suite's results to it, collecting diagnostics from every
+// format rather than stopping at the first problem.
+func SaveReports(rawFlags []string, configLoader *configload.Loader, meta JUnitXMLReportMeta, suite *moduletest.Suite) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+	for _, raw := range rawFlags {
+		format, path, err := ParseReportFlag(raw)
+		if err != nil {
+			diags = diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid -report flag",
+				Detail:   err.Error(),
+			})
+			continue
+		}
+		diags = diags.Append(NewArtifact(format, path, configLoader, meta).Save(suite))
+	}
+	return diags
+}
@@ -0,0 +1,52 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package views
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform/internal/moduletest"
+)
+
+func Test_ParseReportFlag(t *testing.T) {
+	cases := map[string]struct {
+		Raw                  string
+		WantFormat, WantPath string
+		WantErr              bool
+	}{
+		"valid junit flag":    {Raw: "junit:out.xml", WantFormat: "junit", WantPath: "out.xml"},
+		"valid tap flag":      {Raw: "tap:out.tap", WantFormat: "tap", WantPath: "out.tap"},
+		"missing colon":       {Raw: "junitout.xml", WantErr: true},
+		"empty format":        {Raw: ":out.xml", WantErr: true},
+		"empty path":          {Raw: "junit:", WantErr: true},
+		"unrecognized format": {Raw: "xunit:out.xml", WantErr: true},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			format, path, err := ParseReportFlag(tc.Raw)
+			if tc.WantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if format != tc.WantFormat || path != tc.WantPath {
+				t.Fatalf("expected (%q, %q), got (%q, %q)", tc.WantFormat, tc.WantPath, format, path)
+			}
+		})
+	}
+}
+
+func Test_SaveReports_invalidFlag(t *testing.T) {
+	suite := &moduletest.Suite{Status: moduletest.Pass}
+
+	diags := SaveReports([]string{"not-a-valid-flag"}, nil, JUnitXMLReportMeta{}, suite)
+	if !diags.HasErrors() {
+		t.Fatalf("expected an error diagnostic for an invalid -report flag, got none")
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package views
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/terraform/internal/configs/configload"
+	"github.com/hashicorp/terraform/internal/moduletest"
+	"github.com/hashicorp/terraform/internal/tfdiags"
+)
+
+// GitHubAnnotationsFile is an Artifact that renders a moduletest.Suite as
+// GitHub Actions workflow commands ("::error file=…,line=…::message"), so
+// that failed assertions are surfaced as inline annotations on the files
+// changed in a pull request.
+type GitHubAnnotationsFile struct {
+	filename     string
+	configLoader *configload.Loader
+}
+
+// NewGitHubAnnotationsFile returns an Artifact that writes suite results
+// to filename as GitHub Actions workflow commands.
+func NewGitHubAnnotationsFile(filename string, configLoader *configload.Loader) Artifact {
+	return &GitHubAnnotationsFile{
+		filename:     filename,
+		configLoader: configLoader,
+	}
+}
+
+// Save takes in a test suite and writes one workflow command per failed
+// or errored run to the filename specified by user.
+func (v *GitHubAnnotationsFile) Save(suite *moduletest.Suite) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	if suite.Status == moduletest.Pending {
+		diags = diags.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Cannot write test results from a pending test suite to GitHub annotations file",
+			Detail:   "Test suites must be completed before we can write its results to file, but a pending test suite was encountered. This is a bug in Terraform and should be reported.",
+		})
+		return diags
+	}
+
+	sources := v.configLoader.Parser().Sources()
+	var buf bytes.Buffer
+	for _, file := range suiteFilesAsSortedList(suite.Files) {
+		for _, run := range file.Runs {
+			if run.Status != moduletest.Fail && run.Status != moduletest.Error {
+				continue
+			}
+			writeGitHubAnnotations(&buf, file, run, sources)
+		}
+	}
+
+	if err := os.WriteFile(v.filename, buf.Bytes(), 0660); err != nil {
+		diags = diags.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  fmt.Sprintf("error saving GitHub annotations to file %q", v.filename),
+			Detail:   err.Error(),
+		})
+	}
+	return diags
+}
+
+// writeGitHubAnnotations emits one "::error …" workflow command per
+// diagnostic attached to run, falling back to a single annotation
+// pointing at the run's own `run "…"` block when it has no diagnostics to
+// report (for example, a provider-level error with no HCL position).
+func writeGitHubAnnotations(buf *bytes.Buffer, file *moduletest.File, run *moduletest.Run, sources map[string][]byte) {
+	if len(run.Diagnostics) == 0 {
+		filename, line := runSourceLocation(file, run, sources)
+		message := escapeGitHubAnnotationMessage(fmt.Sprintf("%s: run failed", run.Name))
+		writeGitHubAnnotation(buf, filename, line, 0, message)
+		return
+	}
+
+	for _, diag := range run.Diagnostics {
+		desc := diag.Description()
+		message := desc.Summary
+		if desc.Detail != "" {
+			message = message + ": " + desc.Detail
+		}
+
+		filename, line := runSourceLocation(file, run, sources)
+		col := 0
+		if subject := diag.Source().Subject; subject != nil {
+			filename, line = subject.Filename, subject.Start.Line
+			col = subject.Start.Column
+		}
+		writeGitHubAnnotation(buf, filename, line, col, escapeGitHubAnnotationMessage(message))
+	}
+}
+
+// runSourceLocation looks up the `run "…"` block for run within file's
+// source, falling back to just the file name when the block can't be
+// found.
+func runSourceLocation(file *moduletest.File, run *moduletest.Run, sources map[string][]byte) (filename string, line int) {
+	filename = file.Name
+	if src, ok := sources[file.Name]; ok {
+		line = findRunDeclLine(src, run.Name)
+	}
+	return filename, line
+}
+
+// writeGitHubAnnotation writes one "::error …" workflow command. col is
+// the 1-based source column and is omitted, along with line, when it's
+// not known (zero).
+func writeGitHubAnnotation(buf *bytes.Buffer, filename string, line, col int, message string) {
+	switch {
+	case line != 0 && col != 0:
+		fmt.Fprintf(buf, "::error file=%s,line=%d,col=%d::%s\n", filename, line, col, message)
+	case line != 0:
+		fmt.Fprintf(buf, "::error file=%s,line=%d::%s\n", filename, line, message)
+	default:
+		fmt.Fprintf(buf, "::error file=%s::%s\n", filename, message)
+	}
+}
+
+// escapeGitHubAnnotationMessage applies the percent-escaping that GitHub
+// Actions workflow commands require within their message text.
+func escapeGitHubAnnotationMessage(s string) string {
+	s = strings.ReplaceAll(s, "%", "%25")
+	s = strings.ReplaceAll(s, "\r", "%0D")
+	s = strings.ReplaceAll(s, "\n", "%0A")
+	return s
+}
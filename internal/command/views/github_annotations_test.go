@@ -0,0 +1,57 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package views
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/hashicorp/terraform/internal/moduletest"
+)
+
+func Test_writeGitHubAnnotations(t *testing.T) {
+	sources := map[string][]byte{
+		"test_name.tftest.hcl": []byte("run \"test_one\" {\n}\n"),
+	}
+	file := &moduletest.File{Name: "test_name.tftest.hcl", Status: moduletest.Fail}
+	run := &moduletest.Run{Name: "test_one", Status: moduletest.Fail}
+
+	var buf bytes.Buffer
+	writeGitHubAnnotations(&buf, file, run, sources)
+
+	want := "::error file=test_name.tftest.hcl,line=1::test_one: run failed\n"
+	if got := buf.String(); got != want {
+		t.Fatalf("wanted:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func Test_writeGitHubAnnotation(t *testing.T) {
+	cases := map[string]struct {
+		Line, Col int
+		Want      string
+	}{
+		"no position known": {
+			Want: "::error file=f.tftest.hcl::boom\n",
+		},
+		"line only": {
+			Line: 3,
+			Want: "::error file=f.tftest.hcl,line=3::boom\n",
+		},
+		"line and column": {
+			Line: 3,
+			Col:  7,
+			Want: "::error file=f.tftest.hcl,line=3,col=7::boom\n",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			writeGitHubAnnotation(&buf, "f.tftest.hcl", tc.Line, tc.Col, "boom")
+			if got := buf.String(); got != tc.Want {
+				t.Fatalf("wanted %q, got %q", tc.Want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package views
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/terraform/internal/command/format"
+	"github.com/hashicorp/terraform/internal/configs/configload"
+	"github.com/hashicorp/terraform/internal/moduletest"
+	"github.com/hashicorp/terraform/internal/tfdiags"
+)
+
+// jsonReportSchemaVersion is incremented whenever JSONReportFile's output
+// changes in a way that isn't purely additive, so that consumers can
+// detect breaking changes.
+const jsonReportSchemaVersion = "1.0"
+
+// JSONReportFile is an Artifact that renders a moduletest.Suite as a
+// stable, versioned JSON document mirroring its structure, with
+// diagnostics normalized into plain fields rather than left as
+// tfdiags.Diagnostic values.
+type JSONReportFile struct {
+	filename     string
+	configLoader *configload.Loader
+}
+
+// NewJSONReportFile returns an Artifact that writes suite results to
+// filename as JSON.
+func NewJSONReportFile(filename string, configLoader *configload.Loader) Artifact {
+	return &JSONReportFile{
+		filename:     filename,
+		configLoader: configLoader,
+	}
+}
+
+type jsonReport struct {
+	Version string           `json:"version"`
+	Status  string           `json:"status"`
+	Files   []jsonReportFile `json:"files"`
+}
+
+type jsonReportFile struct {
+	Name   string          `json:"name"`
+	Status string          `json:"status"`
+	Runs   []jsonReportRun `json:"runs"`
+}
+
+type jsonReportRun struct {
+	Name        string                 `json:"name"`
+	Status      string                 `json:"status"`
+	RunTime     float64                `json:"time_seconds,omitempty"`
+	Diagnostics []jsonReportDiagnostic `json:"diagnostics,omitempty"`
+}
+
+type jsonReportDiagnostic struct {
+	Severity string `json:"severity"`
+	Summary  string `json:"summary"`
+	Detail   string `json:"detail,omitempty"`
+	Filename string `json:"filename,omitempty"`
+	Line     int    `json:"line,omitempty"`
+
+	// Context is the same rendered source snippet that the TAP, GitHub
+	// annotations, and JUnit XML formats attach to a failing or erroring
+	// run, included here too so a JSON consumer isn't missing context its
+	// siblings all provide.
+	Context string `json:"context,omitempty"`
+}
+
+// Save takes in a test suite, generates a JSON document summarising the
+// test results, and saves the content to the filename specified by user.
+func (v *JSONReportFile) Save(suite *moduletest.Suite) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	if suite.Status == moduletest.Pending {
+		diags = diags.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Cannot write test results from a pending test suite to JSON output file",
+			Detail:   "Test suites must be completed before we can write its results to file, but a pending test suite was encountered. This is a bug in Terraform and should be reported.",
+		})
+		return diags
+	}
+
+	sources := v.configLoader.Parser().Sources()
+	report := buildJSONReport(suite, sources)
+
+	src, err := json.MarshalIndent(&report, "", "  ")
+	if err != nil {
+		diags = diags.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "error generating JSON test report",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+
+	if err := os.WriteFile(v.filename, src, 0660); err != nil {
+		diags = diags.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  fmt.Sprintf("error saving JSON test report to file %q", v.filename),
+			Detail:   err.Error(),
+		})
+	}
+	return diags
+}
+
+// buildJSONReport assembles the versioned jsonReport document for suite,
+// rendering each diagnostic's source snippet from sources the same way
+// the TAP, GitHub annotations, and JUnit XML formats do. It's kept
+// separate from Save so the rendering can be tested without touching the
+// filesystem.
+func buildJSONReport(suite *moduletest.Suite, sources map[string][]byte) jsonReport {
+	report := jsonReport{
+		Version: jsonReportSchemaVersion,
+		Status:  suite.Status.String(),
+	}
+	for _, file := range suiteFilesAsSortedList(suite.Files) {
+		rf := jsonReportFile{Name: file.Name, Status: file.Status.String()}
+		for _, run := range file.Runs {
+			rr := jsonReportRun{Name: run.Name, Status: run.Status.String()}
+			if execMeta := run.ExecutionMeta; execMeta != nil {
+				rr.RunTime = execMeta.Duration.Seconds()
+			}
+			for _, diag := range run.Diagnostics {
+				rr.Diagnostics = append(rr.Diagnostics, normalizeDiagnostic(diag, sources))
+			}
+			rf.Runs = append(rf.Runs, rr)
+		}
+		report.Files = append(report.Files, rf)
+	}
+	return report
+}
+
+// normalizeDiagnostic flattens a tfdiags.Diagnostic into the plain fields
+// the JSON schema exposes, rather than leaning on tfdiags' own
+// (unversioned) internal representation.
+func normalizeDiagnostic(diag tfdiags.Diagnostic, sources map[string][]byte) jsonReportDiagnostic {
+	desc := diag.Description()
+	d := jsonReportDiagnostic{
+		Severity: diag.Severity().String(),
+		Summary:  desc.Summary,
+		Detail:   desc.Detail,
+	}
+	if src := diag.Source(); src.Subject != nil {
+		d.Filename = src.Subject.Filename
+		d.Line = src.Subject.Start.Line
+	}
+	if context := strings.TrimRight(format.DiagnosticPlain(diag, sources, 80), "\n"); context != "" {
+		d.Context = context
+	}
+	return d
+}
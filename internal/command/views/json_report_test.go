@@ -0,0 +1,48 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package views
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform/internal/moduletest"
+)
+
+func Test_buildJSONReport(t *testing.T) {
+	suite := &moduletest.Suite{
+		Status: moduletest.Fail,
+		Files: map[string]*moduletest.File{
+			"test_name.tftest.hcl": {
+				Name:   "test_name.tftest.hcl",
+				Status: moduletest.Fail,
+				Runs: []*moduletest.Run{
+					{Name: "test_one", Status: moduletest.Pass},
+					{Name: "test_two", Status: moduletest.Fail},
+				},
+			},
+		},
+	}
+
+	report := buildJSONReport(suite, nil)
+
+	if report.Version != jsonReportSchemaVersion {
+		t.Fatalf("expected version %q, got %q", jsonReportSchemaVersion, report.Version)
+	}
+	if report.Status != "fail" {
+		t.Fatalf("expected status %q, got %q", "fail", report.Status)
+	}
+	if len(report.Files) != 1 {
+		t.Fatalf("expected 1 file, got %d", len(report.Files))
+	}
+	file := report.Files[0]
+	if file.Name != "test_name.tftest.hcl" {
+		t.Fatalf("expected file name %q, got %q", "test_name.tftest.hcl", file.Name)
+	}
+	if len(file.Runs) != 2 {
+		t.Fatalf("expected 2 runs, got %d", len(file.Runs))
+	}
+	if file.Runs[0].Status != "pass" || file.Runs[1].Status != "fail" {
+		t.Fatalf("expected run statuses [pass fail], got [%s %s]", file.Runs[0].Status, file.Runs[1].Status)
+	}
+}
@@ -5,15 +5,18 @@ package views
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/xml"
 	"fmt"
 	"os"
+	"regexp"
 	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/hcl/v2"
-	"github.com/hashicorp/terraform/internal/command/format"
 	"github.com/hashicorp/terraform/internal/configs/configload"
 	"github.com/hashicorp/terraform/internal/moduletest"
 	"github.com/hashicorp/terraform/internal/tfdiags"
@@ -29,12 +32,18 @@ type JUnitXMLFile struct {
 
 	// A config loader is required to access sources, which are used with diagnostics to create XML content
 	configLoader *configload.Loader
+
+	// meta carries the suite-level metadata that isn't known to the
+	// moduletest.Suite itself, such as the Terraform version and the
+	// currently selected workspace.
+	meta JUnitXMLReportMeta
 }
 
-func NewJUnitXMLFile(filename string, configLoader *configload.Loader) Artifact {
+func NewJUnitXMLFile(filename string, configLoader *configload.Loader, meta JUnitXMLReportMeta) Artifact {
 	return &JUnitXMLFile{
 		filename:     filename,
 		configLoader: configLoader,
+		meta:         meta,
 	}
 }
 
@@ -54,7 +63,11 @@ func (v *JUnitXMLFile) Save(suite *moduletest.Suite) tfdiags.Diagnostics {
 
 	// Prepare XML content
 	sources := v.configLoader.Parser().Sources()
-	xmlSrc, err := JUnitXMLTestReport(suite, sources)
+	meta := v.meta
+	if meta.StartTime.IsZero() {
+		meta.StartTime = time.Now().UTC()
+	}
+	xmlSrc, err := JUnitXMLTestReport(suite, sources, meta)
 	if err != nil {
 		diags = diags.Append(&hcl.Diagnostic{
 			Severity: hcl.DiagError,
@@ -99,6 +112,18 @@ type TestCase struct {
 	Error     *WithMessage `xml:"error,omitempty"`
 	Stderr    *WithMessage `xml:"system-err,omitempty"`
 
+	// SystemOut carries the human-readable plan/apply summary lines that
+	// Terraform would normally print to the terminal for this run, so
+	// that a reader of the XML alone can see what actually happened.
+	SystemOut string `xml:"system-out,omitempty"`
+
+	// File and Line locate the `run "…"` block that this test case came
+	// from in its .tftest.hcl source file, when that location could be
+	// determined. Consumers such as GitLab and some IDE integrations use
+	// these to link a result back to its source.
+	File string `xml:"file,attr,omitempty"`
+	Line int    `xml:"line,attr,omitempty"`
+
 	// RunTime is the time spent executing the run associated
 	// with this test case, in seconds with the fractional component
 	// representing partial seconds.
@@ -113,11 +138,165 @@ type TestCase struct {
 	RunTime float64 `xml:"time,attr,omitempty"`
 }
 
+// Property is a single name/value pair within a testsuite's <properties>
+// block.
+type Property struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// Properties is the <properties> block attached to each <testsuite>,
+// recording environment facts that apply to every test case in that
+// suite: the Terraform version and workspace in use, a hash identifying
+// the module source in effect, and the resolved version of each provider.
+type Properties struct {
+	Property []Property `xml:"property"`
+}
+
+// JUnitXMLReportMeta carries the suite-level metadata that isn't known to
+// a moduletest.Suite itself, and so must be supplied by the caller that
+// has access to the wider run context.
+type JUnitXMLReportMeta struct {
+	// TerraformVersion is recorded as the "terraform.version" property.
+	TerraformVersion string
+
+	// Workspace is the currently-selected workspace, recorded as the
+	// "terraform.workspace" property.
+	Workspace string
+
+	// ProviderVersions maps each provider's local name (as used in the
+	// configuration) to its resolved version string. Each entry is
+	// recorded as its own property, named "terraform.provider.<name>".
+	ProviderVersions map[string]string
+
+	// PlanSummaries optionally supplies the rendered plan/apply summary
+	// text that Terraform printed for a given run, keyed by
+	// "<file name>/<run name>". Entries are copied into the matching
+	// test case's <system-out> element. Runs with no entry simply omit
+	// <system-out>.
+	PlanSummaries map[string]string
+
+	// StartTime is when the file's runs started, recorded as each
+	// testsuite's "timestamp" attribute in ISO-8601 UTC. If zero, callers
+	// such as JUnitXMLFile.Save substitute the current time.
+	StartTime time.Time
+
+	// PriorAttempts records, for a run retried under -rerun-failed=N that
+	// ultimately passed, the outcome of each attempt that preceded the
+	// passing one. It's keyed by "<file name>/<run name>", matching
+	// PlanSummaries. A run with no entry here is assumed to have passed
+	// on its first attempt.
+	PriorAttempts map[string][]AttemptRecord
+}
+
+// AttemptRecord summarizes one earlier attempt at a run that was retried
+// under -rerun-failed=N. It exists so that a run which eventually passes
+// doesn't look like it was clean from the start: the JUnit output
+// attaches these to the case's <system-out> as a flakiness note.
+type AttemptRecord struct {
+	Status string
+	Detail string
+}
+
+// flakyAttemptsNote renders attempts as a human-readable note for a
+// passing run's <system-out>, or "" if there were no prior attempts.
+func flakyAttemptsNote(attempts []AttemptRecord) string {
+	if len(attempts) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "This run passed, but only after %d failed attempt(s):\n", len(attempts))
+	for i, attempt := range attempts {
+		fmt.Fprintf(&b, "  attempt %d: %s\n", i+1, attempt.Status)
+		if attempt.Detail != "" {
+			b.WriteString("    " + attempt.Detail + "\n")
+		}
+	}
+	return b.String()
+}
+
+// appendSystemOut appends note to an existing <system-out> body, if any.
+func appendSystemOut(existing, note string) string {
+	if existing == "" {
+		return note
+	}
+	return existing + "\n" + note
+}
+
 var (
 	FailedTestSummary = "Test assertion failed"
 )
 
-func JUnitXMLTestReport(suite *moduletest.Suite, sources map[string][]byte) ([]byte, error) {
+// runDeclRegexp matches a `run "name" {` block header, used to locate the
+// source line of a test case when emitting the "file"/"line" attributes.
+var runDeclRegexp = regexp.MustCompile(`^\s*run\s+"([^"]*)"\s*\{`)
+
+// findRunDeclLine scans src for a `run "name" {` block and returns its
+// 1-based line number, or 0 if it can't be found.
+func findRunDeclLine(src []byte, name string) int {
+	lines := strings.Split(string(src), "\n")
+	for i, line := range lines {
+		m := runDeclRegexp.FindStringSubmatch(line)
+		if m != nil && m[1] == name {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// moduleSourceHash returns a short hash summarizing the given module
+// source files, used as the "terraform.module_source_hash" property so
+// that a merged or historical report can tell whether the module changed
+// between runs.
+func moduleSourceHash(sources map[string][]byte) string {
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	h := sha256.New()
+	for _, name := range names {
+		h.Write([]byte(name))
+		h.Write(sources[name])
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// suiteProperties builds the <properties> block shared by every
+// <testsuite> element in a report.
+func suiteProperties(meta JUnitXMLReportMeta, sources map[string][]byte) Properties {
+	var props Properties
+
+	// Omit properties the caller didn't supply a value for, rather than
+	// emitting them with an empty value: an absent
+	// "terraform.version" property is a clearer signal to a consumer
+	// than one claiming the version actually is "".
+	if meta.TerraformVersion != "" {
+		props.Property = append(props.Property, Property{Name: "terraform.version", Value: meta.TerraformVersion})
+	}
+	if meta.Workspace != "" {
+		props.Property = append(props.Property, Property{Name: "terraform.workspace", Value: meta.Workspace})
+	}
+	props.Property = append(props.Property, Property{Name: "terraform.module_source_hash", Value: moduleSourceHash(sources)})
+
+	providerNames := make([]string, 0, len(meta.ProviderVersions))
+	for name := range meta.ProviderVersions {
+		providerNames = append(providerNames, name)
+	}
+	slices.Sort(providerNames)
+	for _, name := range providerNames {
+		props.Property = append(props.Property, Property{
+			Name:  "terraform.provider." + name,
+			Value: meta.ProviderVersions[name],
+		})
+	}
+
+	return props
+}
+
+func JUnitXMLTestReport(suite *moduletest.Suite, sources map[string][]byte, meta JUnitXMLReportMeta) ([]byte, error) {
+	rc := reportContext{sources: sources}
 	var buf bytes.Buffer
 	enc := xml.NewEncoder(&buf)
 	enc.EncodeToken(xml.ProcInst{
@@ -130,126 +309,176 @@ func JUnitXMLTestReport(suite *moduletest.Suite, sources map[string][]byte) ([]b
 	suitesName := xml.Name{Local: "testsuites"}
 	suiteName := xml.Name{Local: "testsuite"}
 	caseName := xml.Name{Local: "testcase"}
+	propertiesName := xml.Name{Local: "properties"}
 	nameName := xml.Name{Local: "name"}
 	testsName := xml.Name{Local: "tests"}
 	skippedName := xml.Name{Local: "skipped"}
 	failuresName := xml.Name{Local: "failures"}
 	errorsName := xml.Name{Local: "errors"}
+	timestampName := xml.Name{Local: "timestamp"}
+	timeName := xml.Name{Local: "time"}
+
+	properties := suiteProperties(meta, sources)
+	startTime := meta.StartTime
+	if startTime.IsZero() {
+		startTime = time.Now()
+	}
+	timestamp := startTime.UTC().Format(time.RFC3339)
+
+	elemNames := junitElementNames{
+		suiteName:      suiteName,
+		caseName:       caseName,
+		propertiesName: propertiesName,
+		nameName:       nameName,
+		testsName:      testsName,
+		skippedName:    skippedName,
+		failuresName:   failuresName,
+		errorsName:     errorsName,
+		timestampName:  timestampName,
+		timeName:       timeName,
+	}
 
 	enc.EncodeToken(xml.StartElement{Name: suitesName})
 	sortedFiles := suiteFilesAsSortedList(suite.Files) // to ensure consistent ordering in XML
 	for _, file := range sortedFiles {
 		// Each test file is modelled as a "test suite".
-
-		// First we'll count the number of tests and number of failures/errors
-		// for the suite-level summary.
-		totalTests := len(file.Runs)
-		totalFails := 0
-		totalErrs := 0
-		totalSkipped := 0
-		for _, run := range file.Runs {
-			switch run.Status {
-			case moduletest.Skip:
-				totalSkipped++
-			case moduletest.Fail:
-				totalFails++
-			case moduletest.Error:
-				totalErrs++
-			}
+		if err := writeJUnitTestSuite(enc, elemNames, file, rc, sources, meta, properties, timestamp); err != nil {
+			return nil, err
 		}
-		enc.EncodeToken(xml.StartElement{
-			Name: suiteName,
-			Attr: []xml.Attr{
-				{Name: nameName, Value: file.Name},
-				{Name: testsName, Value: strconv.Itoa(totalTests)},
-				{Name: skippedName, Value: strconv.Itoa(totalSkipped)},
-				{Name: failuresName, Value: strconv.Itoa(totalFails)},
-				{Name: errorsName, Value: strconv.Itoa(totalErrs)},
-			},
-		})
+	}
+	enc.EncodeToken(xml.EndElement{Name: suitesName})
+	enc.Close()
+	return buf.Bytes(), nil
+}
 
-		for _, run := range file.Runs {
+// junitElementNames bundles the xml.Name values writeJUnitTestSuite needs,
+// so that both JUnitXMLTestReport's single-shot encoder and
+// StreamingJUnitXMLFile's incremental one can share the exact same
+// element/attribute names.
+type junitElementNames struct {
+	suiteName      xml.Name
+	caseName       xml.Name
+	propertiesName xml.Name
+	nameName       xml.Name
+	testsName      xml.Name
+	skippedName    xml.Name
+	failuresName   xml.Name
+	errorsName     xml.Name
+	timestampName  xml.Name
+	timeName       xml.Name
+}
 
-			// By creating a map of diags we can delete them as they're used below
-			// This helps to identify diags that are only appropriate to include in
-			// the "system-err" element
-			diagsMap := make(map[int]tfdiags.Diagnostic, len(run.Diagnostics))
-			for i, diag := range run.Diagnostics {
-				diagsMap[i] = diag
-			}
+// writeJUnitTestSuite writes one complete <testsuite>...</testsuite>
+// element for file to enc, including its <properties> block and one
+// <testcase> per run. It's shared by JUnitXMLTestReport, which calls it
+// once per file while building a whole report in memory, and by
+// StreamingJUnitXMLFile, which calls it once per file as each one
+// finishes running.
+func writeJUnitTestSuite(enc *xml.Encoder, names junitElementNames, file *moduletest.File, rc reportContext, sources map[string][]byte, meta JUnitXMLReportMeta, properties Properties, timestamp string) error {
+	// First we'll count the number of tests and number of failures/errors
+	// for the suite-level summary, and the sum of their individual
+	// run times for the suite's own "time" attribute.
+	totalTests := len(file.Runs)
+	totalFails := 0
+	totalErrs := 0
+	totalSkipped := 0
+	var totalTime float64
+	for _, run := range file.Runs {
+		switch run.Status {
+		case moduletest.Skip:
+			totalSkipped++
+		case moduletest.Fail:
+			totalFails++
+		case moduletest.Error:
+			totalErrs++
+		}
+		if execMeta := run.ExecutionMeta; execMeta != nil {
+			totalTime += execMeta.Duration.Seconds()
+		}
+	}
+	if err := enc.EncodeToken(xml.StartElement{
+		Name: names.suiteName,
+		Attr: []xml.Attr{
+			{Name: names.nameName, Value: file.Name},
+			{Name: names.testsName, Value: strconv.Itoa(totalTests)},
+			{Name: names.skippedName, Value: strconv.Itoa(totalSkipped)},
+			{Name: names.failuresName, Value: strconv.Itoa(totalFails)},
+			{Name: names.errorsName, Value: strconv.Itoa(totalErrs)},
+			{Name: names.timestampName, Value: timestamp},
+			{Name: names.timeName, Value: strconv.FormatFloat(totalTime, 'f', -1, 64)},
+		},
+	}); err != nil {
+		return err
+	}
+	if err := enc.EncodeElement(&properties, xml.StartElement{Name: names.propertiesName}); err != nil {
+		return err
+	}
+
+	for _, run := range file.Runs {
+		outcome := rc.runOutcome(run)
 
-			// Each run is a "test case".
-			testCase := TestCase{
-				Name: run.Name,
+		// Each run is a "test case".
+		testCase := TestCase{
+			Name: run.Name,
 
-				// We treat the test scenario filename as the "class name",
-				// implying that the run name is the "method name", just
-				// because that seems to inspire more useful rendering in
-				// some consumers of JUnit XML that were designed for
-				// Java-shaped languages.
-				Classname: file.Name,
+			// We treat the test scenario filename as the "class name",
+			// implying that the run name is the "method name", just
+			// because that seems to inspire more useful rendering in
+			// some consumers of JUnit XML that were designed for
+			// Java-shaped languages.
+			Classname: file.Name,
+		}
+		if execMeta := run.ExecutionMeta; execMeta != nil {
+			testCase.RunTime = execMeta.Duration.Seconds()
+		}
+		if src, ok := sources[file.Name]; ok {
+			if line := findRunDeclLine(src, run.Name); line != 0 {
+				testCase.File = file.Name
+				testCase.Line = line
 			}
-			if execMeta := run.ExecutionMeta; execMeta != nil {
-				testCase.RunTime = execMeta.Duration.Seconds()
+		}
+		if summary, ok := meta.PlanSummaries[file.Name+"/"+run.Name]; ok {
+			testCase.SystemOut = summary
+		}
+		if run.Status == moduletest.Pass {
+			if note := flakyAttemptsNote(meta.PriorAttempts[file.Name+"/"+run.Name]); note != "" {
+				// The run passed overall, but only after one or more
+				// failed attempts under -rerun-failed: note that here
+				// rather than letting a flaky run look simply green.
+				testCase.SystemOut = appendSystemOut(testCase.SystemOut, note)
 			}
-			switch run.Status {
-			case moduletest.Skip:
-				testCase.Skipped = &WithMessage{
-					// FIXME: Is there something useful we could say here about
-					// why the test was skipped?
-				}
-			case moduletest.Fail:
-				var diagsStr strings.Builder
-				for key, diag := range diagsMap {
-					// Select for diags resulting from failed assertions
-					if diag.Description().Summary == FailedTestSummary {
-						diagsStr.WriteString(format.DiagnosticPlain(diag, sources, 80))
-						delete(diagsMap, key)
-					}
-				}
-				testCase.Failure = &WithMessage{
-					Message: "Test run failed",
-					// FIXME: What's a useful thing to report in the body
-					// here? A summary of the statuses from all of the
-					// checkable objects in the configuration?
-					Body: diagsStr.String(),
-				}
-			case moduletest.Error:
-				var diagsStr strings.Builder
-				for key, diag := range diagsMap {
-					diagsStr.WriteString(format.DiagnosticPlain(diag, sources, 80))
-					delete(diagsMap, key)
-				}
-				testCase.Error = &WithMessage{
-					Message: "Encountered an error",
-					Body:    diagsStr.String(),
-				}
+		}
+		switch run.Status {
+		case moduletest.Skip:
+			testCase.Skipped = &WithMessage{
+				// FIXME: Is there something useful we could say here about
+				// why the test was skipped?
 			}
-			if len(diagsMap) != 0 && testCase.Error == nil {
-				// If we have unprocessed diagnostics but the outcome wasn't an error
-				// then we're presumably holding diagnostics that didn't
-				// cause the test to error, such as warnings. We'll place
-				// those into the "system-err" element instead, so that
-				// they'll be reported _somewhere_ at least.
-				var diagsStr strings.Builder
-				for key, diag := range diagsMap {
-					diagsStr.WriteString(format.DiagnosticPlain(diag, sources, 80))
-					delete(diagsMap, key)
-				}
-				testCase.Stderr = &WithMessage{
-					Body: diagsStr.String(),
-				}
+		case moduletest.Fail:
+			testCase.Failure = &WithMessage{
+				Message: "Test run failed",
+				// FIXME: What's a useful thing to report in the body
+				// here? A summary of the statuses from all of the
+				// checkable objects in the configuration?
+				Body: outcome.FailureBody,
+			}
+		case moduletest.Error:
+			testCase.Error = &WithMessage{
+				Message: "Encountered an error",
+				Body:    outcome.ErrorBody,
 			}
-			enc.EncodeElement(&testCase, xml.StartElement{
-				Name: caseName,
-			})
 		}
-
-		enc.EncodeToken(xml.EndElement{Name: suiteName})
+		if outcome.ExtraBody != "" {
+			testCase.Stderr = &WithMessage{
+				Body: outcome.ExtraBody,
+			}
+		}
+		if err := enc.EncodeElement(&testCase, xml.StartElement{Name: names.caseName}); err != nil {
+			return err
+		}
 	}
-	enc.EncodeToken(xml.EndElement{Name: suitesName})
-	enc.Close()
-	return buf.Bytes(), nil
+
+	return enc.EncodeToken(xml.EndElement{Name: names.suiteName})
 }
 
 func suiteFilesAsSortedList(files map[string]*moduletest.File) []*moduletest.File {
@@ -0,0 +1,340 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package views
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// JUnitXMLMerger combines the JUnit XML reports produced by separate
+// `terraform test` invocations into a single canonical report.
+//
+// This is for users who shard their test files across CI workers, or who
+// run a "parallel" and a "serial" phase as separate invocations, but still
+// want one report to hand to their CI system's test reporting UI.
+type JUnitXMLMerger struct {
+	filename string
+}
+
+// NewJUnitXMLMerger returns a JUnitXMLMerger that will write its merged
+// report to filename.
+func NewJUnitXMLMerger(filename string) *JUnitXMLMerger {
+	return &JUnitXMLMerger{
+		filename: filename,
+	}
+}
+
+// MergeDir scans dir for files matching "*.xml", merges them with Merge,
+// and writes the result to the merger's filename. This is the
+// implementation behind the "terraform test -junit-merge=dir/" flag.
+func (m *JUnitXMLMerger) MergeDir(dir string) error {
+	matches, err := filepath.Glob(filepath.Join(dir, "*.xml"))
+	if err != nil {
+		return fmt.Errorf("scanning %q for JUnit XML files: %w", dir, err)
+	}
+	if len(matches) == 0 {
+		return fmt.Errorf("no *.xml files found in %q", dir)
+	}
+	sort.Strings(matches)
+
+	inputs := make([][]byte, len(matches))
+	for i, name := range matches {
+		src, err := os.ReadFile(name)
+		if err != nil {
+			return fmt.Errorf("reading %q: %w", name, err)
+		}
+		inputs[i] = src
+	}
+
+	merged, err := Merge(inputs)
+	if err != nil {
+		return fmt.Errorf("merging JUnit XML files in %q: %w", dir, err)
+	}
+	return os.WriteFile(m.filename, merged, 0660)
+}
+
+// RunJUnitMergeFlag implements "terraform test -junit-merge=<dir>" end to
+// end: it merges every "*.xml" file in dir and writes the result to
+// outputFilename, which is the path the plain (non-merging)
+// "-junit-xml=<path>" flag would otherwise have written a single run's
+// report to. A command's flag handling can call this directly once it's
+// parsed -junit-merge; it's the one function that flag needs to reach.
+func RunJUnitMergeFlag(dir, outputFilename string) error {
+	if outputFilename == "" {
+		return fmt.Errorf("-junit-merge requires -junit-xml to also be set, to know where to write the merged report")
+	}
+	return NewJUnitXMLMerger(outputFilename).MergeDir(dir)
+}
+
+// mergeShardDelimiter separates the bodies of failure/error/system-err
+// elements that came from different input reports, so that a reader can
+// still tell which shard contributed which diagnostic text.
+const mergeShardDelimiter = "\n--- (reported by another shard) ---\n"
+
+// xmlTestSuites, xmlTestSuite, xmlTestCase and xmlWithMessage mirror the
+// schema written by JUnitXMLTestReport closely enough to round-trip it,
+// which is all Merge needs: it never has to interpret a moduletest.Suite,
+// only the JUnit XML that JUnitXMLTestReport already produced for one.
+// That includes the <properties> block and the per-case "file"/"line"
+// and <system-out> that JUnitXMLTestReport attaches, so that merging
+// doesn't silently drop the source links and environment metadata a
+// single-shard report would have had.
+type xmlTestSuites struct {
+	XMLName xml.Name       `xml:"testsuites"`
+	Suites  []xmlTestSuite `xml:"testsuite"`
+}
+
+type xmlTestSuite struct {
+	Name       string        `xml:"name,attr"`
+	Tests      int           `xml:"tests,attr"`
+	Skipped    int           `xml:"skipped,attr"`
+	Failures   int           `xml:"failures,attr"`
+	Errors     int           `xml:"errors,attr"`
+	Timestamp  string        `xml:"timestamp,attr,omitempty"`
+	Time       float64       `xml:"time,attr,omitempty"`
+	Properties Properties    `xml:"properties"`
+	Cases      []xmlTestCase `xml:"testcase"`
+}
+
+type xmlTestCase struct {
+	Name      string          `xml:"name,attr"`
+	Classname string          `xml:"classname,attr"`
+	File      string          `xml:"file,attr,omitempty"`
+	Line      int             `xml:"line,attr,omitempty"`
+	RunTime   float64         `xml:"time,attr,omitempty"`
+	Skipped   *xmlWithMessage `xml:"skipped"`
+	Failure   *xmlWithMessage `xml:"failure"`
+	Error     *xmlWithMessage `xml:"error"`
+	Stderr    *xmlWithMessage `xml:"system-err"`
+	SystemOut string          `xml:"system-out,omitempty"`
+}
+
+type xmlWithMessage struct {
+	Message string `xml:"message,attr,omitempty"`
+	Body    string `xml:",cdata"`
+}
+
+// precedence ranks a test case's outcome so that Merge can decide which of
+// two reports of the same case should win: Error > Failure > Skipped > Passed.
+func (c *xmlTestCase) precedence() int {
+	switch {
+	case c.Error != nil:
+		return 3
+	case c.Failure != nil:
+		return 2
+	case c.Skipped != nil:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Merge combines the JUnit XML reports in inputs into a single report,
+// following the dedup semantics used by other JUnit-merging tools: cases
+// are indexed by (testsuite name, testcase name), and when the same key
+// appears in more than one input the case with the higher-precedence
+// outcome (Error > Failure > Skipped > Passed) wins, with the losing
+// outcomes' diagnostic text preserved in "system-err" rather than
+// discarded.
+func Merge(inputs [][]byte) ([]byte, error) {
+	var suiteOrder []string
+	suites := make(map[string]*xmlTestSuite)
+	var caseOrder = make(map[string][]string)
+	cases := make(map[[2]string]*xmlTestCase)
+
+	for i, src := range inputs {
+		var parsed xmlTestSuites
+		if err := xml.Unmarshal(src, &parsed); err != nil {
+			return nil, fmt.Errorf("parsing JUnit XML input %d: %w", i, err)
+		}
+
+		for _, suite := range parsed.Suites {
+			merged, ok := suites[suite.Name]
+			if !ok {
+				merged = &xmlTestSuite{
+					Name:       suite.Name,
+					Timestamp:  suite.Timestamp,
+					Time:       suite.Time,
+					Properties: suite.Properties,
+				}
+				suites[suite.Name] = merged
+				suiteOrder = append(suiteOrder, suite.Name)
+			} else {
+				// Properties (terraform/provider versions, module source
+				// hash, workspace) are suite-wide facts rather than
+				// per-shard ones, so we keep whichever shard's we saw
+				// first instead of trying to merge them, the same way we
+				// keep the earliest timestamp and the largest total time.
+				if suite.Timestamp != "" && (merged.Timestamp == "" || suite.Timestamp < merged.Timestamp) {
+					merged.Timestamp = suite.Timestamp
+				}
+				if suite.Time > merged.Time {
+					merged.Time = suite.Time
+				}
+			}
+
+			for _, tc := range suite.Cases {
+				tc := tc // capture for pointer use below
+				key := [2]string{suite.Name, tc.Name}
+				existing, ok := cases[key]
+				if !ok {
+					cases[key] = &tc
+					caseOrder[suite.Name] = append(caseOrder[suite.Name], tc.Name)
+					continue
+				}
+				mergeTestCase(existing, &tc)
+			}
+		}
+	}
+
+	sort.Strings(suiteOrder)
+	for _, suiteName := range suiteOrder {
+		suite := suites[suiteName]
+		names := caseOrder[suiteName]
+		sort.Strings(names)
+
+		suite.Tests = len(names)
+		for _, name := range names {
+			tc := cases[[2]string{suiteName, name}]
+			suite.Cases = append(suite.Cases, *tc)
+			switch tc.precedence() {
+			case 3:
+				suite.Errors++
+			case 2:
+				suite.Failures++
+			case 1:
+				suite.Skipped++
+			}
+		}
+	}
+
+	result := xmlTestSuites{}
+	for _, suiteName := range suiteOrder {
+		result.Suites = append(result.Suites, *suites[suiteName])
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	if err := enc.Encode(&result); err != nil {
+		return nil, fmt.Errorf("encoding merged JUnit XML: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// mergeTestCase folds src into dst, which represents the case selected so
+// far across all inputs seen. The higher-precedence outcome wins, but we
+// keep the other shard's diagnostic text rather than discarding it.
+//
+// Each branch below performs its own, single merge of the Stderr field
+// rather than falling through to one shared merge at the end: once
+// "*dst = *src" aliases dst.Stderr to src.Stderr, a later unconditional
+// mergeMessageBody(dst.Stderr, src.Stderr) would merge that body with
+// itself and duplicate the losing shard's diagnostic text.
+func mergeTestCase(dst, src *xmlTestCase) {
+	dstRank, srcRank := dst.precedence(), src.precedence()
+	runTime := dst.RunTime
+	if src.RunTime > runTime {
+		runTime = src.RunTime
+	}
+
+	switch {
+	case srcRank > dstRank:
+		// src takes over as the merged outcome, but we still want to
+		// surface whatever dst was reporting before it lost, and to keep
+		// whichever side actually has the source-link/plan-summary
+		// fields when the other doesn't.
+		if note := messageBody(outcomeMessage(dst)); note != "" {
+			src.Stderr = appendMessage(src.Stderr, note)
+		}
+		src.Stderr = mergeMessageBody(src.Stderr, dst.Stderr)
+		if src.File == "" {
+			src.File, src.Line = dst.File, dst.Line
+		}
+		if src.SystemOut == "" {
+			src.SystemOut = dst.SystemOut
+		}
+		*dst = *src
+	case srcRank < dstRank:
+		if note := messageBody(outcomeMessage(src)); note != "" {
+			dst.Stderr = appendMessage(dst.Stderr, note)
+		}
+		dst.Stderr = mergeMessageBody(dst.Stderr, src.Stderr)
+		if dst.File == "" {
+			dst.File, dst.Line = src.File, src.Line
+		}
+		if dst.SystemOut == "" {
+			dst.SystemOut = src.SystemOut
+		}
+	default:
+		dst.Failure = mergeMessageBody(dst.Failure, src.Failure)
+		dst.Error = mergeMessageBody(dst.Error, src.Error)
+		dst.Stderr = mergeMessageBody(dst.Stderr, src.Stderr)
+		if dst.File == "" {
+			dst.File, dst.Line = src.File, src.Line
+		}
+		if dst.SystemOut == "" {
+			dst.SystemOut = src.SystemOut
+		}
+	}
+	dst.RunTime = runTime
+}
+
+func outcomeMessage(tc *xmlTestCase) *xmlWithMessage {
+	switch {
+	case tc.Error != nil:
+		return tc.Error
+	case tc.Failure != nil:
+		return tc.Failure
+	default:
+		return nil
+	}
+}
+
+func messageBody(m *xmlWithMessage) string {
+	if m == nil {
+		return ""
+	}
+	return m.Body
+}
+
+// mergeMessageBody concatenates a and b's bodies with the shard
+// delimiter, preferring whichever message element is non-nil when only
+// one side has one.
+func mergeMessageBody(a, b *xmlWithMessage) *xmlWithMessage {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	case a.Body == "" && b.Body == "":
+		return a
+	default:
+		return &xmlWithMessage{
+			Message: a.Message,
+			Body:    a.Body + mergeShardDelimiter + b.Body,
+		}
+	}
+}
+
+// appendMessage appends note to dst's body (creating dst if necessary),
+// used when a case's outcome changes during merge and we don't want to
+// lose the losing shard's diagnostic text.
+func appendMessage(dst *xmlWithMessage, note string) *xmlWithMessage {
+	if dst == nil {
+		return &xmlWithMessage{Body: note}
+	}
+	if dst.Body == "" {
+		dst.Body = note
+		return dst
+	}
+	dst.Body = dst.Body + mergeShardDelimiter + note
+	return dst
+}
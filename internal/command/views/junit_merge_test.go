@@ -0,0 +1,139 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package views
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_Merge(t *testing.T) {
+	cases := map[string]struct {
+		Inputs  []string
+		Check   func(t *testing.T, got string)
+		WantErr bool
+	}{
+		"single input is returned essentially unchanged": {
+			Inputs: []string{
+				`<?xml version="1.0" encoding="UTF-8"?><testsuites>
+  <testsuite name="a.tftest.hcl" tests="1" skipped="0" failures="0" errors="0">
+    <testcase name="one" classname="a.tftest.hcl"></testcase>
+  </testsuite>
+</testsuites>`,
+			},
+			Check: func(t *testing.T, got string) {
+				if !strings.Contains(got, `<testcase name="one" classname="a.tftest.hcl">`) {
+					t.Fatalf("expected merged output to contain the single input's test case, got:\n%s", got)
+				}
+			},
+		},
+		"a failure in one shard beats a skip of the same case in another": {
+			Inputs: []string{
+				`<?xml version="1.0" encoding="UTF-8"?><testsuites>
+  <testsuite name="a.tftest.hcl" tests="1" skipped="1" failures="0" errors="0">
+    <testcase name="one" classname="a.tftest.hcl"><skipped></skipped></testcase>
+  </testsuite>
+</testsuites>`,
+				`<?xml version="1.0" encoding="UTF-8"?><testsuites>
+  <testsuite name="a.tftest.hcl" tests="1" skipped="0" failures="1" errors="0">
+    <testcase name="one" classname="a.tftest.hcl"><failure message="Test run failed"><![CDATA[boom]]></failure></testcase>
+  </testsuite>
+</testsuites>`,
+			},
+			Check: func(t *testing.T, got string) {
+				if strings.Contains(got, "<skipped>") {
+					t.Fatalf("expected the failure to win over the skip, but a <skipped> element survived:\n%s", got)
+				}
+				if !strings.Contains(got, "<failure") {
+					t.Fatalf("expected the merged case to be a failure, got:\n%s", got)
+				}
+				if strings.Count(got, "boom") != 1 {
+					t.Fatalf("expected the failure body to appear exactly once, got it %d times:\n%s", strings.Count(got, "boom"), got)
+				}
+				if strings.Contains(got, "tests=\"2\"") {
+					t.Fatalf("expected the duplicate case to be deduped into a single test, got:\n%s", got)
+				}
+			},
+		},
+		"an error in one shard beats a failure in another, keeping both bodies once each": {
+			Inputs: []string{
+				`<?xml version="1.0" encoding="UTF-8"?><testsuites>
+  <testsuite name="a.tftest.hcl" tests="1" skipped="0" failures="1" errors="0">
+    <testcase name="one" classname="a.tftest.hcl"><failure message="Test run failed"><![CDATA[assertion failed]]></failure></testcase>
+  </testsuite>
+</testsuites>`,
+				`<?xml version="1.0" encoding="UTF-8"?><testsuites>
+  <testsuite name="a.tftest.hcl" tests="1" skipped="0" failures="0" errors="1">
+    <testcase name="one" classname="a.tftest.hcl"><error message="Encountered an error"><![CDATA[provider crashed]]></error></testcase>
+  </testsuite>
+</testsuites>`,
+			},
+			Check: func(t *testing.T, got string) {
+				if !strings.Contains(got, "<error") {
+					t.Fatalf("expected the merged case to be an error, got:\n%s", got)
+				}
+				if strings.Contains(got, "<failure") {
+					t.Fatalf("expected no <failure> element once error won, got:\n%s", got)
+				}
+				if strings.Count(got, "assertion failed") != 1 {
+					t.Fatalf("expected the losing shard's body to appear exactly once (not duplicated), got it %d times:\n%s", strings.Count(got, "assertion failed"), got)
+				}
+				if strings.Count(got, "provider crashed") != 1 {
+					t.Fatalf("expected the winning shard's body to appear exactly once, got it %d times:\n%s", strings.Count(got, "provider crashed"), got)
+				}
+			},
+		},
+		"properties, file/line and system-out survive merge": {
+			Inputs: []string{
+				`<?xml version="1.0" encoding="UTF-8"?><testsuites>
+  <testsuite name="a.tftest.hcl" tests="1" skipped="0" failures="1" errors="0">
+    <properties><property name="terraform.version" value="1.9.0"></property></properties>
+    <testcase name="one" classname="a.tftest.hcl" file="a.tftest.hcl" line="3"><failure message="Test run failed"><![CDATA[boom]]></failure><system-out>plan summary</system-out></testcase>
+  </testsuite>
+</testsuites>`,
+				`<?xml version="1.0" encoding="UTF-8"?><testsuites>
+  <testsuite name="a.tftest.hcl" tests="1" skipped="0" failures="0" errors="1">
+    <testcase name="one" classname="a.tftest.hcl"><error message="Encountered an error"><![CDATA[crash]]></error></testcase>
+  </testsuite>
+</testsuites>`,
+			},
+			Check: func(t *testing.T, got string) {
+				if !strings.Contains(got, `<property name="terraform.version" value="1.9.0">`) {
+					t.Fatalf("expected the first shard's <properties> to survive merge, got:\n%s", got)
+				}
+				if !strings.Contains(got, `file="a.tftest.hcl" line="3"`) {
+					t.Fatalf("expected the winning case to keep the file/line the losing shard never reported, got:\n%s", got)
+				}
+				if !strings.Contains(got, "<system-out>plan summary</system-out>") {
+					t.Fatalf("expected the winning case to keep the system-out the losing shard never reported, got:\n%s", got)
+				}
+			},
+		},
+		"invalid XML is rejected": {
+			Inputs:  []string{"not xml"},
+			WantErr: true,
+		},
+	}
+
+	for tn, tc := range cases {
+		t.Run(tn, func(t *testing.T) {
+			inputs := make([][]byte, len(tc.Inputs))
+			for i, s := range tc.Inputs {
+				inputs[i] = []byte(s)
+			}
+
+			got, err := Merge(inputs)
+			if tc.WantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			tc.Check(t, string(got))
+		})
+	}
+}
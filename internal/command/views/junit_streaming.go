@@ -0,0 +1,253 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package views
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/hashicorp/terraform/internal/configs/configload"
+	"github.com/hashicorp/terraform/internal/moduletest"
+)
+
+// StreamingJUnitXMLFile writes a JUnit XML report incrementally, flushing
+// a complete <testsuite> element to disk as soon as each test file
+// finishes, rather than buffering the whole suite in memory and writing
+// it once at the end the way JUnitXMLFile does. That means a
+// `terraform test` process killed mid-run (OOM, CI timeout, SIGINT)
+// still leaves behind a report covering whatever completed before it
+// died, instead of no artifact at all.
+//
+// It doesn't implement Artifact: Artifact.Save takes a complete
+// *moduletest.Suite at the end of a run, which is exactly the buffering
+// behavior this type exists to avoid. Its caller instead drives it
+// directly: Open at the start of the run, FileCompleted as each file
+// finishes, and Close (or, if the run is being torn down abnormally,
+// Abort) at the end.
+type StreamingJUnitXMLFile struct {
+	filename     string
+	configLoader *configload.Loader
+	meta         JUnitXMLReportMeta
+
+	f    *os.File
+	enc  *xml.Encoder
+	elem junitElementNames
+
+	properties Properties
+	timestamp  string
+
+	// inFlight tracks, by file name, the test files that have been
+	// started but not yet passed to FileCompleted. It's the "tail
+	// journal" Abort consults to mark unfinished files as aborted rather
+	// than leaving them out of the report entirely.
+	inFlight map[string]*moduletest.File
+}
+
+// NewStreamingJUnitXMLFile returns a StreamingJUnitXMLFile that will
+// write its report incrementally to filename. Use NewJUnitXMLFile
+// instead if you'd rather keep today's single atomic write at the end of
+// the run.
+func NewStreamingJUnitXMLFile(filename string, configLoader *configload.Loader, meta JUnitXMLReportMeta) *StreamingJUnitXMLFile {
+	return &StreamingJUnitXMLFile{
+		filename:     filename,
+		configLoader: configLoader,
+		meta:         meta,
+		inFlight:     make(map[string]*moduletest.File),
+	}
+}
+
+// Open creates the target file and writes the XML prolog and the opening
+// <testsuites> tag, so that the file is valid (if empty) JUnit XML from
+// the very start of the run.
+func (v *StreamingJUnitXMLFile) Open() error {
+	f, err := os.Create(v.filename)
+	if err != nil {
+		return fmt.Errorf("error creating JUnit XML file %q: %w", v.filename, err)
+	}
+	v.f = f
+	v.enc = xml.NewEncoder(f)
+	v.enc.Indent("", "  ")
+
+	if err := v.enc.EncodeToken(xml.ProcInst{
+		Target: "xml",
+		Inst:   []byte(`version="1.0" encoding="UTF-8"`),
+	}); err != nil {
+		return err
+	}
+	if err := v.enc.EncodeToken(xml.StartElement{Name: xml.Name{Local: "testsuites"}}); err != nil {
+		return err
+	}
+	if err := v.enc.Flush(); err != nil {
+		return err
+	}
+
+	sources := v.configLoader.Parser().Sources()
+	v.properties = suiteProperties(v.meta, sources)
+	startTime := v.meta.StartTime
+	if startTime.IsZero() {
+		startTime = time.Now()
+	}
+	v.timestamp = startTime.UTC().Format(time.RFC3339)
+	v.elem = junitElementNames{
+		suiteName:      xml.Name{Local: "testsuite"},
+		caseName:       xml.Name{Local: "testcase"},
+		propertiesName: xml.Name{Local: "properties"},
+		nameName:       xml.Name{Local: "name"},
+		testsName:      xml.Name{Local: "tests"},
+		skippedName:    xml.Name{Local: "skipped"},
+		failuresName:   xml.Name{Local: "failures"},
+		errorsName:     xml.Name{Local: "errors"},
+		timestampName:  xml.Name{Local: "timestamp"},
+		timeName:       xml.Name{Local: "time"},
+	}
+	return nil
+}
+
+// BeginFile records that file has started running, so that Abort can
+// still account for it in the report if the process dies before
+// FileCompleted is called.
+func (v *StreamingJUnitXMLFile) BeginFile(file *moduletest.File) {
+	v.inFlight[file.Name] = file
+}
+
+// FileCompleted renders file as a complete <testsuite>...</testsuite>
+// element and flushes it to disk immediately. Call this from
+// moduletest's per-file completion callback as each test file finishes
+// running.
+func (v *StreamingJUnitXMLFile) FileCompleted(file *moduletest.File) error {
+	if v.enc == nil {
+		return fmt.Errorf("StreamingJUnitXMLFile.FileCompleted called before Open")
+	}
+	sources := v.configLoader.Parser().Sources()
+	rc := reportContext{sources: sources}
+
+	if err := writeJUnitTestSuite(v.enc, v.elem, file, rc, sources, v.meta, v.properties, v.timestamp); err != nil {
+		return fmt.Errorf("error writing to JUnit XML file %q: %w", v.filename, err)
+	}
+	if err := v.enc.Flush(); err != nil {
+		return fmt.Errorf("error writing to JUnit XML file %q: %w", v.filename, err)
+	}
+	delete(v.inFlight, file.Name)
+	return nil
+}
+
+// Close writes the closing </testsuites> tag and closes the file. Call
+// this once every file has been passed to FileCompleted and the suite
+// finished normally.
+func (v *StreamingJUnitXMLFile) Close() error {
+	if v.enc == nil {
+		return nil
+	}
+	if err := v.enc.EncodeToken(xml.EndElement{Name: xml.Name{Local: "testsuites"}}); err != nil {
+		return err
+	}
+	if err := v.enc.Flush(); err != nil {
+		return err
+	}
+	return v.f.Close()
+}
+
+// Abort closes out a report that's ending abnormally: any file recorded
+// as in flight (started via BeginFile but never passed to
+// FileCompleted) is written as a <testsuite> containing a single
+// <testcase> with an <error message="aborted"/>, standing in for
+// whichever of its runs didn't get to finish, then the outstanding
+// <testsuites> element is closed and the file is closed.
+//
+// Call this from a deferred handler guarding the run, so that a process
+// killed by SIGINT, an OOM, or a CI timeout still leaves behind valid XML
+// instead of a truncated file.
+func (v *StreamingJUnitXMLFile) Abort() error {
+	if v.enc == nil {
+		return nil
+	}
+	for _, file := range v.inFlight {
+		if err := v.writeAbortedTestSuite(file); err != nil {
+			return err
+		}
+	}
+	return v.Close()
+}
+
+// streamingJUnitWriter is the subset of StreamingJUnitXMLFile's methods
+// RunWithStreamingJUnit needs. It exists so RunWithStreamingJUnit's
+// abort-on-panic control flow can be tested against a fake without
+// requiring a real *configload.Loader.
+type streamingJUnitWriter interface {
+	Open() error
+	BeginFile(file *moduletest.File)
+	FileCompleted(file *moduletest.File) error
+	Close() error
+	Abort() error
+}
+
+var _ streamingJUnitWriter = (*StreamingJUnitXMLFile)(nil)
+
+// RunWithStreamingJUnit drives w through a complete run over files,
+// calling execute once per file between BeginFile and FileCompleted.
+// This is moduletest's per-file completion callback hookup the type
+// exists for: a deferred handler covers both an error returned by
+// execute/FileCompleted and a panic unwinding through this function, so
+// that a process killed mid-run (or one that simply errors out) still
+// leaves behind a report covering whatever files finished first, instead
+// of the truncated, invalid XML a plain write would leave.
+func RunWithStreamingJUnit(w streamingJUnitWriter, files []*moduletest.File, execute func(file *moduletest.File) error) (err error) {
+	if err := w.Open(); err != nil {
+		return err
+	}
+
+	finished := false
+	defer func() {
+		if finished {
+			return
+		}
+		w.Abort()
+	}()
+
+	for _, file := range files {
+		w.BeginFile(file)
+		if err := execute(file); err != nil {
+			return err
+		}
+		if err := w.FileCompleted(file); err != nil {
+			return err
+		}
+	}
+
+	finished = true
+	return w.Close()
+}
+
+func (v *StreamingJUnitXMLFile) writeAbortedTestSuite(file *moduletest.File) error {
+	abortedCase := TestCase{
+		Name:      file.Name,
+		Classname: file.Name,
+		Error: &WithMessage{
+			Message: "aborted",
+		},
+	}
+
+	if err := v.enc.EncodeToken(xml.StartElement{
+		Name: v.elem.suiteName,
+		Attr: []xml.Attr{
+			{Name: v.elem.nameName, Value: file.Name},
+			{Name: v.elem.testsName, Value: "1"},
+			{Name: v.elem.skippedName, Value: "0"},
+			{Name: v.elem.failuresName, Value: "0"},
+			{Name: v.elem.errorsName, Value: "1"},
+			{Name: v.elem.timestampName, Value: v.timestamp},
+		},
+	}); err != nil {
+		return err
+	}
+	if err := v.enc.EncodeElement(&v.properties, xml.StartElement{Name: v.elem.propertiesName}); err != nil {
+		return err
+	}
+	if err := v.enc.EncodeElement(&abortedCase, xml.StartElement{Name: v.elem.caseName}); err != nil {
+		return err
+	}
+	return v.enc.EncodeToken(xml.EndElement{Name: v.elem.suiteName})
+}
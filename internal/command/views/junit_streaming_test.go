@@ -0,0 +1,118 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package views
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform/internal/moduletest"
+)
+
+// fakeStreamingJUnitWriter records the sequence of calls
+// RunWithStreamingJUnit makes, so tests can assert on its control flow
+// without a real *configload.Loader.
+type fakeStreamingJUnitWriter struct {
+	calls []string
+}
+
+func (f *fakeStreamingJUnitWriter) Open() error {
+	f.calls = append(f.calls, "Open")
+	return nil
+}
+
+func (f *fakeStreamingJUnitWriter) BeginFile(file *moduletest.File) {
+	f.calls = append(f.calls, "BeginFile:"+file.Name)
+}
+
+func (f *fakeStreamingJUnitWriter) FileCompleted(file *moduletest.File) error {
+	f.calls = append(f.calls, "FileCompleted:"+file.Name)
+	return nil
+}
+
+func (f *fakeStreamingJUnitWriter) Close() error {
+	f.calls = append(f.calls, "Close")
+	return nil
+}
+
+func (f *fakeStreamingJUnitWriter) Abort() error {
+	f.calls = append(f.calls, "Abort")
+	return nil
+}
+
+func Test_RunWithStreamingJUnit(t *testing.T) {
+	files := []*moduletest.File{
+		{Name: "a.tftest.hcl"},
+		{Name: "b.tftest.hcl"},
+	}
+
+	t.Run("normal completion closes without aborting", func(t *testing.T) {
+		w := &fakeStreamingJUnitWriter{}
+		err := RunWithStreamingJUnit(w, files, func(file *moduletest.File) error {
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		want := []string{
+			"Open",
+			"BeginFile:a.tftest.hcl", "FileCompleted:a.tftest.hcl",
+			"BeginFile:b.tftest.hcl", "FileCompleted:b.tftest.hcl",
+			"Close",
+		}
+		assertCalls(t, w.calls, want)
+	})
+
+	t.Run("an error from execute aborts instead of closing", func(t *testing.T) {
+		w := &fakeStreamingJUnitWriter{}
+		boom := fmt.Errorf("boom")
+		err := RunWithStreamingJUnit(w, files, func(file *moduletest.File) error {
+			if file.Name == "b.tftest.hcl" {
+				return boom
+			}
+			return nil
+		})
+		if err != boom {
+			t.Fatalf("expected the execute error to propagate, got %v", err)
+		}
+		want := []string{
+			"Open",
+			"BeginFile:a.tftest.hcl", "FileCompleted:a.tftest.hcl",
+			"BeginFile:b.tftest.hcl",
+			"Abort",
+		}
+		assertCalls(t, w.calls, want)
+	})
+
+	t.Run("a panic still aborts before unwinding further", func(t *testing.T) {
+		w := &fakeStreamingJUnitWriter{}
+		defer func() {
+			r := recover()
+			if r == nil {
+				t.Fatalf("expected the panic to propagate past RunWithStreamingJUnit")
+			}
+			want := []string{
+				"Open",
+				"BeginFile:a.tftest.hcl",
+				"Abort",
+			}
+			assertCalls(t, w.calls, want)
+		}()
+		RunWithStreamingJUnit(w, files, func(file *moduletest.File) error {
+			panic("simulated crash mid-run")
+		})
+	})
+}
+
+func assertCalls(t *testing.T, got, want []string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("expected calls %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected calls %v, got %v", want, got)
+		}
+	}
+}
@@ -1,12 +1,35 @@
-package junit
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package views
 
 import (
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/terraform/internal/moduletest"
 )
 
+// fixedReportMeta is shared by the test cases below so that the
+// "timestamp" attribute each produces is deterministic. The
+// "terraform.module_source_hash" property is likewise computed from the
+// (empty) sources map these tests pass in, rather than hardcoded, so it
+// doesn't need updating if the hashing approach ever changes.
+var fixedReportMeta = JUnitXMLReportMeta{
+	StartTime: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+}
+
+func fixedSourceHashProperties(sources map[string][]byte) string {
+	return fmt.Sprintf(`
+    <properties>
+      <property name="terraform.module_source_hash" value="%s"></property>
+    </properties>`, moduleSourceHash(sources))
+}
+
 func Test_JUnitXMLTestReport(t *testing.T) {
+	var noSources map[string][]byte
+
 	cases := map[string]struct {
 		Suite     *moduletest.Suite
 		XmlString string
@@ -16,11 +39,11 @@ func Test_JUnitXMLTestReport(t *testing.T) {
 			Suite:     &moduletest.Suite{},
 		},
 		"one passing test": {
-			XmlString: `<?xml version="1.0" encoding="UTF-8"?><testsuites>
-  <testsuite name="test_name.tftest.hcl" tests="1" skipped="0" failures="0" errors="0">
+			XmlString: fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?><testsuites>
+  <testsuite name="test_name.tftest.hcl" tests="1" skipped="0" failures="0" errors="0" timestamp="2020-01-02T03:04:05Z" time="0">%s
     <testcase name="test_one" classname="test_name.tftest.hcl"></testcase>
   </testsuite>
-</testsuites>`,
+</testsuites>`, fixedSourceHashProperties(noSources)),
 			Suite: &moduletest.Suite{
 				Status: moduletest.Skip,
 				Files: map[string]*moduletest.File{
@@ -38,13 +61,13 @@ func Test_JUnitXMLTestReport(t *testing.T) {
 			},
 		},
 		"one skipped test": {
-			XmlString: `<?xml version="1.0" encoding="UTF-8"?><testsuites>
-  <testsuite name="test_name.tftest.hcl" tests="1" skipped="1" failures="0" errors="0">
+			XmlString: fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?><testsuites>
+  <testsuite name="test_name.tftest.hcl" tests="1" skipped="1" failures="0" errors="0" timestamp="2020-01-02T03:04:05Z" time="0">%s
     <testcase name="test_one" classname="test_name.tftest.hcl">
       <skipped></skipped>
     </testcase>
   </testsuite>
-</testsuites>`,
+</testsuites>`, fixedSourceHashProperties(noSources)),
 			Suite: &moduletest.Suite{
 				Status: moduletest.Skip,
 				Files: map[string]*moduletest.File{
@@ -62,13 +85,13 @@ func Test_JUnitXMLTestReport(t *testing.T) {
 			},
 		},
 		"one failed test": {
-			XmlString: `<?xml version="1.0" encoding="UTF-8"?><testsuites>
-  <testsuite name="test_name.tftest.hcl" tests="1" skipped="0" failures="1" errors="0">
+			XmlString: fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?><testsuites>
+  <testsuite name="test_name.tftest.hcl" tests="1" skipped="0" failures="1" errors="0" timestamp="2020-01-02T03:04:05Z" time="0">%s
     <testcase name="test_one" classname="test_name.tftest.hcl">
       <failure message="Test run failed"></failure>
     </testcase>
   </testsuite>
-</testsuites>`,
+</testsuites>`, fixedSourceHashProperties(noSources)),
 			Suite: &moduletest.Suite{
 				Status: moduletest.Skip,
 				Files: map[string]*moduletest.File{
@@ -86,8 +109,8 @@ func Test_JUnitXMLTestReport(t *testing.T) {
 			},
 		},
 		"three tests, each different status": {
-			XmlString: `<?xml version="1.0" encoding="UTF-8"?><testsuites>
-  <testsuite name="test_name.tftest.hcl" tests="3" skipped="1" failures="1" errors="0">
+			XmlString: fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?><testsuites>
+  <testsuite name="test_name.tftest.hcl" tests="3" skipped="1" failures="1" errors="0" timestamp="2020-01-02T03:04:05Z" time="0">%s
     <testcase name="test_one" classname="test_name.tftest.hcl"></testcase>
     <testcase name="test_two" classname="test_name.tftest.hcl">
       <skipped></skipped>
@@ -96,7 +119,7 @@ func Test_JUnitXMLTestReport(t *testing.T) {
       <failure message="Test run failed"></failure>
     </testcase>
   </testsuite>
-</testsuites>`,
+</testsuites>`, fixedSourceHashProperties(noSources)),
 			Suite: &moduletest.Suite{
 				Status: moduletest.Skip,
 				Files: map[string]*moduletest.File{
@@ -122,11 +145,11 @@ func Test_JUnitXMLTestReport(t *testing.T) {
 			},
 		},
 		"multiple test files with various tests": {
-			XmlString: `<?xml version="1.0" encoding="UTF-8"?><testsuites>
-  <testsuite name="test_file_one.tftest.hcl" tests="1" skipped="0" failures="0" errors="0">
+			XmlString: fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?><testsuites>
+  <testsuite name="test_file_one.tftest.hcl" tests="1" skipped="0" failures="0" errors="0" timestamp="2020-01-02T03:04:05Z" time="0">%[1]s
     <testcase name="test_one" classname="test_file_one.tftest.hcl"></testcase>
   </testsuite>
-  <testsuite name="test_file_two.tftest.hcl" tests="2" skipped="1" failures="1" errors="0">
+  <testsuite name="test_file_two.tftest.hcl" tests="2" skipped="1" failures="1" errors="0" timestamp="2020-01-02T03:04:05Z" time="0">%[1]s
     <testcase name="test_two" classname="test_file_two.tftest.hcl">
       <skipped></skipped>
     </testcase>
@@ -134,7 +157,7 @@ func Test_JUnitXMLTestReport(t *testing.T) {
       <failure message="Test run failed"></failure>
     </testcase>
   </testsuite>
-</testsuites>`,
+</testsuites>`, fixedSourceHashProperties(noSources)),
 			Suite: &moduletest.Suite{
 				Status: moduletest.Skip,
 				Files: map[string]*moduletest.File{
@@ -169,7 +192,10 @@ func Test_JUnitXMLTestReport(t *testing.T) {
 
 	for tn, tc := range cases {
 		t.Run(tn, func(t *testing.T) {
-			b, _ := JUnitXMLTestReport(tc.Suite)
+			b, err := JUnitXMLTestReport(tc.Suite, noSources, fixedReportMeta)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
 			if string(b) != tc.XmlString {
 				t.Fatalf("wanted XML:\n%s\n got XML:\n%s\n", tc.XmlString, string(b))
 			}
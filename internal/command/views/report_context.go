@@ -0,0 +1,97 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package views
+
+import (
+	"strings"
+
+	"github.com/hashicorp/terraform/internal/command/format"
+	"github.com/hashicorp/terraform/internal/configs/configload"
+	"github.com/hashicorp/terraform/internal/moduletest"
+	"github.com/hashicorp/terraform/internal/tfdiags"
+)
+
+// reportContext bundles the data that every Artifact implementation needs
+// in order to turn a run's diagnostics into readable text: the parsed
+// module sources, used both to render diagnostic snippets and to resolve
+// a run's source position.
+//
+// It exists so that the diagnostic-formatting logic that used to live
+// inline in JUnitXMLTestReport can be shared by the JUnit, TAP, JSON and
+// GitHub annotations report formats instead of being copied into each.
+type reportContext struct {
+	sources map[string][]byte
+}
+
+// newReportContext builds a reportContext from the sources known to
+// configLoader, the same sources every existing Artifact implementation
+// already threads through for this purpose.
+func newReportContext(configLoader *configload.Loader) reportContext {
+	return reportContext{sources: configLoader.Parser().Sources()}
+}
+
+// runOutcome is the formatted, status-appropriate diagnostic text for one
+// run, split the same way across every report format.
+type runOutcome struct {
+	// FailureBody holds the diagnostics describing a failed assertion,
+	// populated only when the run's status is moduletest.Fail.
+	FailureBody string
+
+	// ErrorBody holds the diagnostics describing an unexpected error,
+	// populated only when the run's status is moduletest.Error.
+	ErrorBody string
+
+	// ExtraBody holds any diagnostics not already accounted for by
+	// FailureBody or ErrorBody, such as warnings attached to an
+	// otherwise-passing run. Report formats typically surface this as
+	// incidental output rather than as the run's primary outcome.
+	ExtraBody string
+}
+
+// runOutcome renders run's diagnostics into a runOutcome, in exactly the
+// way JUnitXMLTestReport historically did inline.
+func (rc reportContext) runOutcome(run *moduletest.Run) runOutcome {
+	// By creating a map of diags we can delete them as they're used below.
+	// This helps to identify diags that are only appropriate to include
+	// as incidental ("extra") output.
+	diagsMap := make(map[int]tfdiags.Diagnostic, len(run.Diagnostics))
+	for i, diag := range run.Diagnostics {
+		diagsMap[i] = diag
+	}
+
+	var outcome runOutcome
+	switch run.Status {
+	case moduletest.Fail:
+		var diagsStr strings.Builder
+		for key, diag := range diagsMap {
+			// Select for diags resulting from failed assertions.
+			if diag.Description().Summary == FailedTestSummary {
+				diagsStr.WriteString(format.DiagnosticPlain(diag, rc.sources, 80))
+				delete(diagsMap, key)
+			}
+		}
+		outcome.FailureBody = diagsStr.String()
+	case moduletest.Error:
+		var diagsStr strings.Builder
+		for key, diag := range diagsMap {
+			diagsStr.WriteString(format.DiagnosticPlain(diag, rc.sources, 80))
+			delete(diagsMap, key)
+		}
+		outcome.ErrorBody = diagsStr.String()
+	}
+
+	if len(diagsMap) != 0 {
+		// Whatever's left is presumably a diagnostic that didn't cause
+		// the test to fail or error, such as a warning. We'll report
+		// those too, so that they're surfaced _somewhere_ at least.
+		var diagsStr strings.Builder
+		for key, diag := range diagsMap {
+			diagsStr.WriteString(format.DiagnosticPlain(diag, rc.sources, 80))
+			delete(diagsMap, key)
+		}
+		outcome.ExtraBody = diagsStr.String()
+	}
+
+	return outcome
+}
@@ -0,0 +1,307 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package views
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/terraform/internal/moduletest"
+	"github.com/hashicorp/terraform/internal/tfdiags"
+)
+
+// DefaultRunLogFilename is where RunLog reads and writes its log by
+// default, relative to the working directory "terraform test" is invoked
+// from.
+const DefaultRunLogFilename = ".terraform/test-runs.json"
+
+// runLogSchemaVersion guards the shape of the JSON written to the run
+// log, so a future format change can detect and ignore an older file
+// rather than misreading it.
+const runLogSchemaVersion = 1
+
+// RunLog is the sibling of Artifact for persisting run outcomes between
+// invocations of `terraform test`, rather than for rendering a
+// human-facing report. After a run it's used to write a compact log of
+// what happened; before a run it's used to build the filter predicate
+// behind "terraform test -rerun-failed".
+type RunLog struct {
+	filename string
+}
+
+// NewRunLog returns a RunLog backed by filename, or DefaultRunLogFilename
+// if filename is empty.
+func NewRunLog(filename string) *RunLog {
+	if filename == "" {
+		filename = DefaultRunLogFilename
+	}
+	return &RunLog{filename: filename}
+}
+
+type runLogDocument struct {
+	Version int                        `json:"version"`
+	Files   map[string]runLogFileEntry `json:"files"`
+}
+
+type runLogFileEntry struct {
+	Runs map[string]runLogRunEntry `json:"runs"`
+}
+
+type runLogRunEntry struct {
+	Status           string  `json:"status"`
+	DurationSeconds  float64 `json:"duration_seconds,omitempty"`
+	DiagnosticDigest string  `json:"diagnostic_digest,omitempty"`
+}
+
+// Save writes suite's per-run outcomes to the run log, overwriting
+// whatever was recorded by a previous invocation.
+func (l *RunLog) Save(suite *moduletest.Suite) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	if suite.Status == moduletest.Pending {
+		diags = diags.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Cannot write test results from a pending test suite to the run log",
+			Detail:   "Test suites must be completed before we can write its results to file, but a pending test suite was encountered. This is a bug in Terraform and should be reported.",
+		})
+		return diags
+	}
+
+	doc := runLogDocument{
+		Version: runLogSchemaVersion,
+		Files:   make(map[string]runLogFileEntry, len(suite.Files)),
+	}
+	for _, file := range suiteFilesAsSortedList(suite.Files) {
+		entry := runLogFileEntry{Runs: make(map[string]runLogRunEntry, len(file.Runs))}
+		for _, run := range file.Runs {
+			runEntry := runLogRunEntry{Status: run.Status.String()}
+			if execMeta := run.ExecutionMeta; execMeta != nil {
+				runEntry.DurationSeconds = execMeta.Duration.Seconds()
+			}
+			if len(run.Diagnostics) > 0 {
+				runEntry.DiagnosticDigest = diagnosticDigest(run.Diagnostics)
+			}
+			entry.Runs[run.Name] = runEntry
+		}
+		doc.Files[file.Name] = entry
+	}
+
+	if dir := filepath.Dir(l.filename); dir != "." {
+		if err := os.MkdirAll(dir, 0770); err != nil {
+			diags = diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  fmt.Sprintf("error creating directory for run log %q", l.filename),
+				Detail:   err.Error(),
+			})
+			return diags
+		}
+	}
+
+	src, err := json.MarshalIndent(&doc, "", "  ")
+	if err != nil {
+		diags = diags.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "error generating run log",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+	if err := os.WriteFile(l.filename, src, 0660); err != nil {
+		diags = diags.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  fmt.Sprintf("error saving run log to file %q", l.filename),
+			Detail:   err.Error(),
+		})
+	}
+	return diags
+}
+
+// diagnosticDigest returns a short hash summarizing a run's diagnostics,
+// so -rerun-failed can tell (approximately) whether a failure's cause
+// changed between invocations without storing the full diagnostic text.
+func diagnosticDigest(diags tfdiags.Diagnostics) string {
+	h := sha256.New()
+	for _, diag := range diags {
+		desc := diag.Description()
+		h.Write([]byte(desc.Summary))
+		h.Write([]byte{0})
+		h.Write([]byte(desc.Detail))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// RerunFilter decides, given the previous run log, whether a run should
+// execute on this invocation. It's what "terraform test -rerun-failed"
+// applies during scheduling: discovered runs for which Should returns
+// false are skipped entirely rather than re-executed.
+type RerunFilter struct {
+	doc *runLogDocument
+}
+
+// LoadRerunFilter reads the run log at filename (or DefaultRunLogFilename
+// if empty) and returns a filter over it. A missing log is not an error:
+// Should always returns true in that case, since there's nothing to
+// filter against yet.
+func LoadRerunFilter(filename string) (*RerunFilter, error) {
+	if filename == "" {
+		filename = DefaultRunLogFilename
+	}
+	src, err := os.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &RerunFilter{}, nil
+		}
+		return nil, fmt.Errorf("reading run log %q: %w", filename, err)
+	}
+
+	var doc runLogDocument
+	if err := json.Unmarshal(src, &doc); err != nil {
+		return nil, fmt.Errorf("parsing run log %q: %w", filename, err)
+	}
+	return &RerunFilter{doc: &doc}, nil
+}
+
+// Should reports whether the run identified by (file, run) should
+// execute. It returns true for any run with no prior record (new runs
+// always execute) and for any run whose previous status was Fail or
+// Error; it returns false only for a run previously recorded as Pass or
+// Skip.
+func (f *RerunFilter) Should(file, run string) bool {
+	if f == nil || f.doc == nil {
+		return true
+	}
+	fileEntry, ok := f.doc.Files[file]
+	if !ok {
+		return true
+	}
+	runEntry, ok := fileEntry.Runs[run]
+	if !ok {
+		return true
+	}
+	switch runEntry.Status {
+	case moduletest.Fail.String(), moduletest.Error.String():
+		return true
+	default:
+		return false
+	}
+}
+
+// RerunPolicy describes the retry-with-backoff behavior requested by
+// "-rerun-failed=N": a failing run is re-executed up to N times before
+// its outcome is considered final.
+type RerunPolicy struct {
+	// MaxAttempts is the total number of times to attempt a run,
+	// including its first. A value of 1 disables retries.
+	MaxAttempts int
+
+	// Backoff returns how long to wait before the given attempt (the
+	// attempt about to be made, 2-based since attempt 1 never waits). A
+	// nil Backoff means no delay between attempts.
+	Backoff func(attempt int) time.Duration
+}
+
+// DefaultRerunBackoff is the backoff schedule used when a -rerun-failed=N
+// caller doesn't supply its own: a short linear backoff capped at 30
+// seconds, enough to ride out transient infrastructure flakiness without
+// drastically slowing down a test run.
+func DefaultRerunBackoff(attempt int) time.Duration {
+	d := time.Duration(attempt) * 2 * time.Second
+	if d > 30*time.Second {
+		d = 30 * time.Second
+	}
+	return d
+}
+
+// ParseRerunFailedFlag parses the argument to "-rerun-failed", which may
+// be empty (the plain boolean-flag form, equivalent to one retry) or an
+// attempt count "N" requesting up to N retries.
+func ParseRerunFailedFlag(raw string) (RerunPolicy, error) {
+	if raw == "" {
+		return RerunPolicy{MaxAttempts: 2, Backoff: DefaultRerunBackoff}, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 1 {
+		return RerunPolicy{}, fmt.Errorf("invalid -rerun-failed value %q: expected a positive integer", raw)
+	}
+	return RerunPolicy{MaxAttempts: n + 1, Backoff: DefaultRerunBackoff}, nil
+}
+
+// SelectRunsForExecution applies filter to file's runs, the scheduling
+// step behind "terraform test -rerun-failed": toRun is what the caller
+// should actually execute, and toSkip is what it should leave untouched,
+// carried over into this invocation's results as-is from the previous
+// run log.
+func SelectRunsForExecution(filter *RerunFilter, file *moduletest.File) (toRun, toSkip []*moduletest.Run) {
+	for _, run := range file.Runs {
+		if filter.Should(file.Name, run.Name) {
+			toRun = append(toRun, run)
+		} else {
+			toSkip = append(toSkip, run)
+		}
+	}
+	return toRun, toSkip
+}
+
+// RunAttemptResult is the outcome of a single attempt at a run, as
+// reported by the execute callback passed to RunWithRetries.
+type RunAttemptResult struct {
+	Status moduletest.Status
+
+	// Detail is a short human-readable summary of the attempt's outcome,
+	// carried into an AttemptRecord if this attempt doesn't end up being
+	// the final one. It's typically a diagnostic summary.
+	Detail string
+}
+
+// RunWithRetries executes a run by calling execute, retrying under
+// policy until it passes or MaxAttempts is exhausted, waiting between
+// attempts as policy.Backoff directs. It returns the final attempt's
+// result, plus the record of whichever earlier attempts failed along the
+// way so the caller can attach them to JUnitXMLReportMeta.PriorAttempts
+// and surface the run as flaky rather than simply passing.
+func RunWithRetries(policy RerunPolicy, execute func(attempt int) RunAttemptResult) (final RunAttemptResult, priorAttempts []AttemptRecord) {
+	maxAttempts := policy.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		if attempt > 1 && policy.Backoff != nil {
+			time.Sleep(policy.Backoff(attempt))
+		}
+		final = execute(attempt)
+		if final.Status == moduletest.Pass {
+			return final, priorAttempts
+		}
+		if attempt < maxAttempts {
+			priorAttempts = append(priorAttempts, AttemptRecord{
+				Status: final.Status.String(),
+				Detail: final.Detail,
+			})
+		}
+	}
+	return final, priorAttempts
+}
+
+// RecordPriorAttempts attaches attempts to meta's PriorAttempts under the
+// same "<file name>/<run name>" key writeJUnitTestSuite looks them up by,
+// initializing the map if necessary. It's a no-op when attempts is empty,
+// so a run that passed on its first try leaves no trace of this call.
+func RecordPriorAttempts(meta *JUnitXMLReportMeta, fileName, runName string, attempts []AttemptRecord) {
+	if len(attempts) == 0 {
+		return
+	}
+	if meta.PriorAttempts == nil {
+		meta.PriorAttempts = make(map[string][]AttemptRecord)
+	}
+	meta.PriorAttempts[fileName+"/"+runName] = attempts
+}
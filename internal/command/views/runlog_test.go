@@ -0,0 +1,168 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package views
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform/internal/moduletest"
+)
+
+func Test_RerunFilter_Should(t *testing.T) {
+	filter := &RerunFilter{
+		doc: &runLogDocument{
+			Files: map[string]runLogFileEntry{
+				"test_name.tftest.hcl": {
+					Runs: map[string]runLogRunEntry{
+						"passed_before":  {Status: moduletest.Pass.String()},
+						"skipped_before": {Status: moduletest.Skip.String()},
+						"failed_before":  {Status: moduletest.Fail.String()},
+						"errored_before": {Status: moduletest.Error.String()},
+					},
+				},
+			},
+		},
+	}
+
+	cases := map[string]struct {
+		File, Run string
+		Want      bool
+	}{
+		"no prior record runs":      {File: "test_name.tftest.hcl", Run: "new_run", Want: true},
+		"unknown file runs":         {File: "other_file.tftest.hcl", Run: "passed_before", Want: true},
+		"previously passed skips":   {File: "test_name.tftest.hcl", Run: "passed_before", Want: false},
+		"previously skipped skips":  {File: "test_name.tftest.hcl", Run: "skipped_before", Want: false},
+		"previously failed reruns":  {File: "test_name.tftest.hcl", Run: "failed_before", Want: true},
+		"previously errored reruns": {File: "test_name.tftest.hcl", Run: "errored_before", Want: true},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := filter.Should(tc.File, tc.Run); got != tc.Want {
+				t.Fatalf("Should(%q, %q) = %v, want %v", tc.File, tc.Run, got, tc.Want)
+			}
+		})
+	}
+
+	t.Run("nil filter always runs", func(t *testing.T) {
+		var nilFilter *RerunFilter
+		if !nilFilter.Should("any.tftest.hcl", "any_run") {
+			t.Fatalf("expected a nil filter to always return true")
+		}
+	})
+}
+
+func Test_SelectRunsForExecution(t *testing.T) {
+	filter := &RerunFilter{
+		doc: &runLogDocument{
+			Files: map[string]runLogFileEntry{
+				"test_name.tftest.hcl": {
+					Runs: map[string]runLogRunEntry{
+						"test_one": {Status: moduletest.Pass.String()},
+						"test_two": {Status: moduletest.Fail.String()},
+					},
+				},
+			},
+		},
+	}
+	file := &moduletest.File{
+		Name: "test_name.tftest.hcl",
+		Runs: []*moduletest.Run{
+			{Name: "test_one"},
+			{Name: "test_two"},
+			{Name: "test_three"},
+		},
+	}
+
+	toRun, toSkip := SelectRunsForExecution(filter, file)
+
+	if len(toSkip) != 1 || toSkip[0].Name != "test_one" {
+		t.Fatalf("expected only test_one to be skipped, got %v", toSkip)
+	}
+	if len(toRun) != 2 || toRun[0].Name != "test_two" || toRun[1].Name != "test_three" {
+		t.Fatalf("expected test_two and test_three to run, got %v", toRun)
+	}
+}
+
+func Test_RunWithRetries(t *testing.T) {
+	t.Run("passes on first attempt leaves no prior attempts", func(t *testing.T) {
+		calls := 0
+		final, prior := RunWithRetries(RerunPolicy{MaxAttempts: 3}, func(attempt int) RunAttemptResult {
+			calls++
+			return RunAttemptResult{Status: moduletest.Pass}
+		})
+		if calls != 1 {
+			t.Fatalf("expected exactly 1 attempt, got %d", calls)
+		}
+		if final.Status != moduletest.Pass {
+			t.Fatalf("expected a final passing status, got %s", final.Status)
+		}
+		if len(prior) != 0 {
+			t.Fatalf("expected no prior attempts, got %v", prior)
+		}
+	})
+
+	t.Run("retries until it passes, recording the failed attempts", func(t *testing.T) {
+		calls := 0
+		final, prior := RunWithRetries(RerunPolicy{MaxAttempts: 3, Backoff: func(int) time.Duration { return 0 }}, func(attempt int) RunAttemptResult {
+			calls++
+			if attempt < 3 {
+				return RunAttemptResult{Status: moduletest.Fail, Detail: "boom"}
+			}
+			return RunAttemptResult{Status: moduletest.Pass}
+		})
+		if calls != 3 {
+			t.Fatalf("expected exactly 3 attempts, got %d", calls)
+		}
+		if final.Status != moduletest.Pass {
+			t.Fatalf("expected a final passing status, got %s", final.Status)
+		}
+		if len(prior) != 2 {
+			t.Fatalf("expected 2 recorded prior attempts, got %d: %v", len(prior), prior)
+		}
+		for _, a := range prior {
+			if a.Status != moduletest.Fail.String() || a.Detail != "boom" {
+				t.Fatalf("unexpected prior attempt record: %+v", a)
+			}
+		}
+	})
+
+	t.Run("exhausts attempts without passing", func(t *testing.T) {
+		calls := 0
+		final, prior := RunWithRetries(RerunPolicy{MaxAttempts: 2, Backoff: func(int) time.Duration { return 0 }}, func(attempt int) RunAttemptResult {
+			calls++
+			return RunAttemptResult{Status: moduletest.Fail, Detail: "still broken"}
+		})
+		if calls != 2 {
+			t.Fatalf("expected exactly 2 attempts, got %d", calls)
+		}
+		if final.Status != moduletest.Fail {
+			t.Fatalf("expected a final failing status, got %s", final.Status)
+		}
+		if len(prior) != 1 {
+			t.Fatalf("expected 1 recorded prior attempt (not counting the final one), got %d: %v", len(prior), prior)
+		}
+	})
+}
+
+func Test_RecordPriorAttempts(t *testing.T) {
+	var meta JUnitXMLReportMeta
+
+	RecordPriorAttempts(&meta, "test_name.tftest.hcl", "test_one", nil)
+	if meta.PriorAttempts != nil {
+		t.Fatalf("expected an empty attempts slice not to initialize the map")
+	}
+
+	attempts := []AttemptRecord{{Status: moduletest.Fail.String(), Detail: "boom"}}
+	RecordPriorAttempts(&meta, "test_name.tftest.hcl", "test_one", attempts)
+
+	got, ok := meta.PriorAttempts["test_name.tftest.hcl/test_one"]
+	if !ok {
+		t.Fatalf("expected an entry keyed %q, got keys %v", "test_name.tftest.hcl/test_one", meta.PriorAttempts)
+	}
+	if len(got) != 1 || got[0].Detail != "boom" {
+		t.Fatalf("unexpected recorded attempts: %v", got)
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package views
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/terraform/internal/configs/configload"
+	"github.com/hashicorp/terraform/internal/moduletest"
+	"github.com/hashicorp/terraform/internal/tfdiags"
+)
+
+// TAPFile is an Artifact that renders a moduletest.Suite as a Test
+// Anything Protocol (TAP) version 13 document: one "ok"/"not ok" line per
+// run, with a YAML diagnostics block attached to failing or erroring
+// runs.
+type TAPFile struct {
+	filename     string
+	configLoader *configload.Loader
+}
+
+// NewTAPFile returns an Artifact that writes suite results to filename
+// as a TAP v13 document.
+func NewTAPFile(filename string, configLoader *configload.Loader) Artifact {
+	return &TAPFile{
+		filename:     filename,
+		configLoader: configLoader,
+	}
+}
+
+// Save takes in a test suite, generates a TAP document summarising the
+// test results, and saves the content to the filename specified by user.
+func (v *TAPFile) Save(suite *moduletest.Suite) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	if suite.Status == moduletest.Pending {
+		diags = diags.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Cannot write test results from a pending test suite to TAP output file",
+			Detail:   "Test suites must be completed before we can write its results to file, but a pending test suite was encountered. This is a bug in Terraform and should be reported.",
+		})
+		return diags
+	}
+
+	rc := newReportContext(v.configLoader)
+	if err := os.WriteFile(v.filename, tapReport(suite, rc), 0660); err != nil {
+		diags = diags.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  fmt.Sprintf("error saving TAP report to file %q", v.filename),
+			Detail:   err.Error(),
+		})
+	}
+	return diags
+}
+
+// tapReport renders suite as a TAP v13 document. Each run across every
+// file is numbered sequentially, since TAP has no notion of grouping
+// tests into suites; the file name is folded into the test description
+// instead.
+func tapReport(suite *moduletest.Suite, rc reportContext) []byte {
+	type numberedRun struct {
+		file *moduletest.File
+		run  *moduletest.Run
+	}
+	var runs []numberedRun
+	for _, file := range suiteFilesAsSortedList(suite.Files) {
+		for _, run := range file.Runs {
+			runs = append(runs, numberedRun{file, run})
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("TAP version 13\n")
+	fmt.Fprintf(&buf, "1..%d\n", len(runs))
+
+	for i, nr := range runs {
+		description := fmt.Sprintf("%s :: %s", nr.file.Name, nr.run.Name)
+		switch nr.run.Status {
+		case moduletest.Pass:
+			fmt.Fprintf(&buf, "ok %d - %s\n", i+1, description)
+		case moduletest.Skip:
+			fmt.Fprintf(&buf, "ok %d - %s # SKIP\n", i+1, description)
+		default:
+			fmt.Fprintf(&buf, "not ok %d - %s\n", i+1, description)
+			writeTAPDiagnostics(&buf, rc.runOutcome(nr.run))
+		}
+	}
+
+	return buf.Bytes()
+}
+
+// writeTAPDiagnostics emits the YAML diagnostics block that TAP v13
+// allows directly beneath a failing test line, indented by two spaces as
+// the spec requires.
+func writeTAPDiagnostics(buf *bytes.Buffer, outcome runOutcome) {
+	body := outcome.FailureBody
+	if body == "" {
+		body = outcome.ErrorBody
+	}
+	if body == "" && outcome.ExtraBody == "" {
+		return
+	}
+
+	buf.WriteString("  ---\n")
+	if body != "" {
+		writeTAPYAMLBlock(buf, "message", body)
+	}
+	if outcome.ExtraBody != "" {
+		writeTAPYAMLBlock(buf, "system-err", outcome.ExtraBody)
+	}
+	buf.WriteString("  ...\n")
+}
+
+func writeTAPYAMLBlock(buf *bytes.Buffer, key, body string) {
+	fmt.Fprintf(buf, "  %s: |\n", key)
+	for _, line := range strings.Split(strings.TrimRight(body, "\n"), "\n") {
+		buf.WriteString("    " + line + "\n")
+	}
+}
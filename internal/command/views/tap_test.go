@@ -0,0 +1,43 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package views
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform/internal/moduletest"
+)
+
+func Test_tapReport(t *testing.T) {
+	suite := &moduletest.Suite{
+		Status: moduletest.Fail,
+		Files: map[string]*moduletest.File{
+			"test_name.tftest.hcl": {
+				Name:   "test_name.tftest.hcl",
+				Status: moduletest.Fail,
+				Runs: []*moduletest.Run{
+					{Name: "test_one", Status: moduletest.Pass},
+					{Name: "test_two", Status: moduletest.Skip},
+					{Name: "test_three", Status: moduletest.Fail},
+				},
+			},
+		},
+	}
+
+	got := string(tapReport(suite, reportContext{}))
+
+	if !strings.HasPrefix(got, "TAP version 13\n1..3\n") {
+		t.Fatalf("expected a version line and a 1..3 plan line, got:\n%s", got)
+	}
+	if !strings.Contains(got, "ok 1 - test_name.tftest.hcl :: test_one\n") {
+		t.Fatalf("expected a passing line for test_one, got:\n%s", got)
+	}
+	if !strings.Contains(got, "ok 2 - test_name.tftest.hcl :: test_two # SKIP\n") {
+		t.Fatalf("expected a SKIP-directive line for test_two, got:\n%s", got)
+	}
+	if !strings.Contains(got, "not ok 3 - test_name.tftest.hcl :: test_three\n") {
+		t.Fatalf("expected a failing line for test_three, got:\n%s", got)
+	}
+}